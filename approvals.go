@@ -0,0 +1,161 @@
+/*
+QUORATE `approvals` subcommand
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"quorate/internal/config"
+	nsclient "quorate/internal/ns-client"
+	"strconv"
+	"strings"
+)
+
+// approvalsCmd fetches a proposal's approving delegates and checks which of
+// their regions are hittable (or deltippable) given -endos, writing the
+// resulting []Hit as JSON to stdout or -output so it can be piped straight
+// into `quorate triggers`. -useragent and -endos follow the same CLI flag >
+// QUORATE_* env var > quorate.toml (including per-proposal overrides)
+// precedence as `run`, so this subcommand stays cron-friendly too.
+func approvalsCmd(args []string) int {
+	flagSet := flag.NewFlagSet("approvals", flag.ExitOnError)
+	var proposalId string
+	var userAgent string
+	var maxEndoCount int
+	var isMinor bool
+	var output string
+	var workers int
+	flagSet.StringVar(&proposalId, "proposal", "", "The proposal ID")
+	flagSet.StringVar(&userAgent, "useragent", "", "Your user agent")
+	flagSet.IntVar(&maxEndoCount, "endos", -1, "The maximum endorsement count for a target")
+	flagSet.BoolVar(&isMinor, "minor", false, "Use if generating times for minor")
+	flagSet.StringVar(&output, "output", "-", "Where to write the resulting JSON ('-' for stdout)")
+	flagSet.IntVar(&workers, "workers", nsclient.DefaultWorkers, "Number of concurrent region lookups to run")
+	_ = flagSet.Parse(args)
+
+	if proposalId == "" {
+		proposalId = os.Getenv("QUORATE_PROPOSAL")
+	}
+	if userAgent == "" {
+		userAgent = os.Getenv("QUORATE_USERAGENT")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if userAgent == "" {
+		userAgent = cfg.UserAgent
+	}
+	cfgEndos, _, _, _ := cfg.ForProposal(proposalId)
+	if maxEndoCount < 1 {
+		if envEndos, envErr := strconv.Atoi(os.Getenv("QUORATE_ENDOS")); envErr == nil {
+			maxEndoCount = envEndos
+		} else if cfgEndos > 0 {
+			maxEndoCount = cfgEndos
+		}
+	}
+
+	if userAgent == "" || proposalId == "" || maxEndoCount < 1 {
+		log.Print("-useragent, -proposal, and -endos (>=1) are required (or set via QUORATE_* env vars / quorate.toml)")
+		return 1
+	}
+	nsclient.SetUserAgent(userAgent)
+
+	hittable, err := fetchHittable(proposalId, maxEndoCount, isMinor, workers)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Printf("Checks done! %d regions are hittable!\n", len(hittable))
+
+	encoded, err := json.Marshal(hittable)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	if output == "-" {
+		os.Stdout.Write(encoded)
+		os.Stdout.Write([]byte("\n"))
+		return 0
+	}
+
+	if err := os.WriteFile(output, encoded, 0644); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+// fetchHittable walks a proposal's approving delegates, looking up each
+// delegate's region and checking whether its WA delegate can be unseated
+// (or deltipped) within maxEndoCount endorsements. Region lookups run
+// across a pool of workers (see nsclient.BatchRegionInfo); the client's
+// shared rate limiter holds the pool back once a response reports the
+// budget is nearly spent, but does not cap the initial burst of requests.
+func fetchHittable(proposalId string, maxEndoCount int, isMinor bool, workers int) ([]Hit, error) {
+	log.Printf("Getting approvals on proposal %s...\n", proposalId)
+	approvals, err := nsclient.GetProposalApprovals(proposalId)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("%d approvals found!\n", len(approvals))
+	log.Println("Checking which regions can be hit (this may take a while)...")
+
+	results := nsclient.BatchRegionInfo(approvals, workers)
+
+	var hittable []Hit
+	for i, result := range results {
+		approval := approvals[i]
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		regionInfo := result.Info
+		if regionInfo.Password {
+			continue
+		}
+		region := strings.Replace(strings.ToLower(result.Region), " ", "_", -1)
+		var updateTime int64
+		if isMinor {
+			updateTime = regionInfo.LastMinor
+		} else {
+			updateTime = regionInfo.LastMajor
+		}
+
+		if regionInfo.DelEndos < maxEndoCount {
+			hit := Hit{Name: region, Delegate: approval, IsDeltip: false, UpdateTime: updateTime}
+			hittable = append(hittable, hit)
+			log.Printf("Region %s with delegate %s can be hit!\n", region, approval)
+		} else if regionInfo.DelEndos < regionInfo.SecondEndos+maxEndoCount {
+			hit := Hit{Name: region, Delegate: approval, SecondNation: regionInfo.SecondNation, IsDeltip: true,
+				UpdateTime: updateTime}
+			hittable = append(hittable, hit)
+			log.Printf("Region %s with delegate %s can be deltipped by nation %s!\n", region, approval,
+				regionInfo.SecondNation)
+		}
+	}
+
+	return hittable, nil
+}
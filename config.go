@@ -0,0 +1,48 @@
+/*
+QUORATE `config` subcommand
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"log"
+	"quorate/internal/config"
+)
+
+// configCmd groups config-file maintenance verbs. Right now the only one
+// is `quorate config init`, which writes a commented quorate.toml template
+// to the resolved config path.
+func configCmd(args []string) int {
+	if len(args) == 0 || args[0] != "init" {
+		log.Print("usage: quorate config init")
+		return 2
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	if err := config.WriteTemplate(path); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	log.Printf("Wrote config template to %s\n", path)
+	return 0
+}
@@ -0,0 +1,71 @@
+/*
+QUORATE `dump` subcommand
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"quorate/internal/config"
+	nsclient "quorate/internal/ns-client"
+)
+
+// dumpCmd downloads the daily regions dump into the XDG cache dir, where it
+// is kept gzip-compressed rather than unpacked, skipping the download if the cached copy is less than 24h old
+// unless -redownload is given. It's intentionally side-effect-only (no
+// stdout payload) so it can be run from cron ahead of the other
+// subcommands. The user agent follows the same CLI flag > QUORATE_USERAGENT
+// env var > quorate.toml precedence as `run`, so the dump fetcher can be
+// reused as a cron job without forcing it into every invocation.
+func dumpCmd(args []string) int {
+	flagSet := flag.NewFlagSet("dump", flag.ExitOnError)
+	var userAgent string
+	var reDownDump bool
+	flagSet.StringVar(&userAgent, "useragent", "", "Your user agent")
+	flagSet.BoolVar(&reDownDump, "redownload", false, "Redownload the daily dump even if a cached copy is still fresh")
+	_ = flagSet.Parse(args)
+
+	if userAgent == "" {
+		userAgent = os.Getenv("QUORATE_USERAGENT")
+	}
+	if userAgent == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		userAgent = cfg.UserAgent
+	}
+
+	if userAgent == "" {
+		log.Print("a -useragent is required (or set QUORATE_USERAGENT / useragent in quorate.toml)")
+		return 1
+	}
+	nsclient.SetUserAgent(userAgent)
+
+	log.Println("Getting region dump...")
+	path, err := nsclient.GetRegionDump(reDownDump)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Printf("Region dump saved to %s!\n", path)
+
+	return 0
+}
@@ -0,0 +1,203 @@
+/*
+QUORATE's persistent config file
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package config reads and writes quorate.toml, the optional persistent
+// config that lets `quorate run` go fully non-interactive. The file uses a
+// small subset of TOML (bare "key = value" pairs plus "[proposal_id]"
+// sections for per-proposal overrides) that's parsed without pulling in a
+// TOML dependency.
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"quorate/internal/paths"
+	"strconv"
+	"strings"
+)
+
+const FileName = "quorate.toml"
+
+// Override holds per-proposal settings from a "[proposal_id]" section. A
+// nil field means "not overridden, fall back to the global Config value".
+type Override struct {
+	MaxEndoCount   *int
+	MinimumTrigger *int
+	IsMinor        *bool
+}
+
+// Config is the effective contents of quorate.toml.
+type Config struct {
+	UserAgent      string
+	MaxEndoCount   int
+	MinimumTrigger int
+	IsMinor        bool
+	IsMinorSet     bool
+	Overrides      map[string]Override
+}
+
+// Path returns where quorate.toml is expected to live.
+func Path() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads and parses quorate.toml. A missing file is not an error; it
+// just yields an empty Config, so every field not set by a CLI flag or
+// env var falls through to the interactive prompt.
+func Load() (Config, error) {
+	cfg := Config{Overrides: map[string]Override{}}
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, exists := cfg.Overrides[section]; !exists {
+				cfg.Overrides[section] = Override{}
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		if section == "" {
+			applyGlobal(&cfg, key, value)
+		} else {
+			override := cfg.Overrides[section]
+			applyOverride(&override, key, value)
+			cfg.Overrides[section] = override
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func applyGlobal(cfg *Config, key string, value string) {
+	switch key {
+	case "useragent":
+		cfg.UserAgent = value
+	case "endos":
+		cfg.MaxEndoCount, _ = strconv.Atoi(value)
+	case "mintrig":
+		cfg.MinimumTrigger, _ = strconv.Atoi(value)
+	case "update":
+		cfg.IsMinor = value == "minor"
+		cfg.IsMinorSet = true
+	}
+}
+
+func applyOverride(o *Override, key string, value string) {
+	switch key {
+	case "endos":
+		if n, err := strconv.Atoi(value); err == nil {
+			o.MaxEndoCount = &n
+		}
+	case "mintrig":
+		if n, err := strconv.Atoi(value); err == nil {
+			o.MinimumTrigger = &n
+		}
+	case "update":
+		isMinor := value == "minor"
+		o.IsMinor = &isMinor
+	}
+}
+
+// ForProposal merges the global config with a proposal's override (if any
+// section matches id), returning the effective endos/mintrig/isMinor for
+// that proposal.
+func (c Config) ForProposal(id string) (maxEndoCount int, minimumTrigger int, isMinor bool, isMinorSet bool) {
+	maxEndoCount, minimumTrigger, isMinor, isMinorSet = c.MaxEndoCount, c.MinimumTrigger, c.IsMinor, c.IsMinorSet
+
+	override, ok := c.Overrides[id]
+	if !ok {
+		return
+	}
+	if override.MaxEndoCount != nil {
+		maxEndoCount = *override.MaxEndoCount
+	}
+	if override.MinimumTrigger != nil {
+		minimumTrigger = *override.MinimumTrigger
+	}
+	if override.IsMinor != nil {
+		isMinor = *override.IsMinor
+		isMinorSet = true
+	}
+	return
+}
+
+// WriteTemplate writes a commented, ready-to-edit quorate.toml to path,
+// creating its parent directory if necessary. It refuses to clobber an
+// existing file.
+func WriteTemplate(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	if err := paths.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(template), 0644)
+}
+
+const template = `# quorate config
+# Every value here can still be overridden by a CLI flag or QUORATE_* env var.
+
+# useragent = "your_main_nation"
+# endos = 500
+# mintrig = 10
+# update = "minor" # or "major"
+
+# Per-proposal overrides, keyed by proposal ID:
+# [proposal_id_12312312]
+# endos = 300
+# mintrig = 15
+# update = "major"
+`
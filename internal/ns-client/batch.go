@@ -0,0 +1,119 @@
+/*
+QUORATE's NationStates API client - batched region lookups
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import "sync"
+
+// DefaultWorkers is the fan-out BatchRegionInfo uses when workers <= 0.
+const DefaultWorkers = 4
+
+// RegionQueryResult is one nation's resolved region and that region's info,
+// as produced by BatchRegionInfo. Err is set (with Info left zero) if
+// resolving that nation failed; Region is also left zero if the nation's
+// region lookup failed, but is kept if only the region info lookup failed.
+// An error doesn't abort the rest of the batch.
+type RegionQueryResult struct {
+	Nation string
+	Region string
+	Info   RegionInfo
+	Err    error
+}
+
+// nationRegionCache and regionInfoCache make repeat (nation, shard)
+// lookups within a run free; they're shared across all BatchRegionInfo
+// calls for the lifetime of the process.
+var nationRegionCache sync.Map // nation -> string
+var regionInfoCache sync.Map   // region -> RegionInfo
+
+// BatchRegionInfo resolves each nation's region and that region's info
+// across a pool of workers (DefaultWorkers if workers <= 0), returning
+// results in the same order as nations regardless of completion order.
+// Workers share the package's rate limiter: once a response reports 7 or
+// fewer requests remaining, or a 429 arrives, every worker waits out that
+// window. Requests sent before any such response are not held back, so up
+// to `workers` requests can go out at once regardless of the budget.
+func BatchRegionInfo(nations []string, workers int) []RegionQueryResult {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	results := make([]RegionQueryResult, len(nations))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = resolveNation(nations[idx])
+			}
+		}()
+	}
+
+	for i := range nations {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func resolveNation(nation string) RegionQueryResult {
+	region, err := cachedNationRegion(nation)
+	if err != nil {
+		return RegionQueryResult{Nation: nation, Err: err}
+	}
+
+	info, err := cachedRegionInfo(region)
+	if err != nil {
+		return RegionQueryResult{Nation: nation, Region: region, Err: err}
+	}
+
+	return RegionQueryResult{Nation: nation, Region: region, Info: info}
+}
+
+func cachedNationRegion(nation string) (string, error) {
+	if cached, ok := nationRegionCache.Load(nation); ok {
+		return cached.(string), nil
+	}
+
+	region, err := GetNationRegion(nation)
+	if err != nil {
+		return "", err
+	}
+
+	nationRegionCache.Store(nation, region)
+	return region, nil
+}
+
+func cachedRegionInfo(region string) (RegionInfo, error) {
+	if cached, ok := regionInfoCache.Load(region); ok {
+		return cached.(RegionInfo), nil
+	}
+
+	info, err := GetRegioninfo(region)
+	if err != nil {
+		return RegionInfo{}, err
+	}
+
+	regionInfoCache.Store(region, info)
+	return info, nil
+}
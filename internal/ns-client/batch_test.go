@@ -0,0 +1,222 @@
+/*
+QUORATE's NationStates API client - request scheduling tests
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// redirectTransport sends every request to an httptest.Server regardless of
+// the URL it was built against, so makeAPIRequest's hardcoded apiUrl can be
+// exercised without a real network call.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	redirected := req.Clone(req.Context())
+	redirected.URL.Scheme = t.target.Scheme
+	redirected.URL.Host = t.target.Host
+	redirected.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(redirected)
+}
+
+const nationRegionXML = `<NATION><REGION>Testregionia</REGION></NATION>`
+const regionInfoXML = `<REGION><CENSUSRANKS><NATIONS>` +
+	`<NATION><NAME>top_nation</NAME><SCORE>5</SCORE></NATION>` +
+	`<NATION><NAME>second_nation</NAME><SCORE>3</SCORE></NATION>` +
+	`</NATIONS></CENSUSRANKS><TAGS></TAGS>` +
+	`<LASTMAJORUPDATE>100</LASTMAJORUPDATE><LASTMINORUPDATE>50</LASTMINORUPDATE></REGION>`
+
+// withTestClient points the package-level default client (and a freshly
+// reset rate limiter and lookup caches) at server for the duration of a
+// test, restoring the prior state on cleanup. BatchRegionInfo and friends
+// only ever talk to defaultClient, so tests redirect that.
+func withTestClient(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing test server URL: %v", err)
+	}
+
+	prevClient := defaultClient
+	apiLimiter.mu.Lock()
+	prevBlockedUntil := apiLimiter.blockedUntil
+	prevNextRelease := apiLimiter.nextRelease
+	apiLimiter.mu.Unlock()
+
+	t.Cleanup(func() {
+		defaultClient = prevClient
+		apiLimiter.mu.Lock()
+		apiLimiter.blockedUntil = prevBlockedUntil
+		apiLimiter.nextRelease = prevNextRelease
+		apiLimiter.mu.Unlock()
+	})
+
+	defaultClient = NewClient(Options{Transport: redirectTransport{target: target}})
+	defaultClient.SetUserAgent("test_nation")
+	apiLimiter.mu.Lock()
+	apiLimiter.blockedUntil = time.Time{}
+	apiLimiter.nextRelease = time.Time{}
+	apiLimiter.mu.Unlock()
+	nationRegionCache = sync.Map{}
+	regionInfoCache = sync.Map{}
+}
+
+// TestBatchRegionInfoHonorsRateLimitHeaders fires a pool of concurrent
+// BatchRegionInfo workers at a server that advertises a tight
+// RateLimit-Remaining/RateLimit-Reset budget on its first response, then
+// plenty of headroom afterwards. It asserts the shared limiter actually
+// throttles the pool (the next request doesn't fire until roughly the
+// advertised reset window has passed) rather than every worker hammering
+// the server on its own schedule.
+func TestBatchRegionInfoHonorsRateLimitHeaders(t *testing.T) {
+	var reqCount int32
+	var mu sync.Mutex
+	var reqTimes []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqTimes = append(reqTimes, time.Now())
+		mu.Unlock()
+
+		n := atomic.AddInt32(&reqCount, 1)
+		if n == 1 {
+			// Advertise a nearly-exhausted budget on the very first
+			// response; the limiter should hold every other worker back
+			// for about a second (observe()'s minimum backoff).
+			w.Header().Set("RateLimit-Remaining", "1")
+			w.Header().Set("RateLimit-Reset", "0")
+		} else {
+			w.Header().Set("RateLimit-Remaining", "50")
+			w.Header().Set("RateLimit-Reset", "30")
+		}
+
+		_ = r.ParseForm()
+		if r.FormValue("q") == "region" {
+			fmt.Fprint(w, nationRegionXML)
+		} else {
+			fmt.Fprint(w, regionInfoXML)
+		}
+	}))
+	defer server.Close()
+
+	withTestClient(t, server)
+
+	const nationCount = 8
+	const workers = 4
+	nations := make([]string, nationCount)
+	for i := range nations {
+		nations[i] = "nation_" + strconv.Itoa(i)
+	}
+
+	start := time.Now()
+	results := BatchRegionInfo(nations, workers)
+	elapsed := time.Since(start)
+
+	if len(results) != nationCount {
+		t.Fatalf("expected %d results, got %d", nationCount, len(results))
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("result %d: unexpected error: %v", i, result.Err)
+		}
+		if result.Info.DelEndos != 5 || result.Info.SecondEndos != 3 {
+			t.Fatalf("result %d: unexpected region info: %+v", i, result.Info)
+		}
+	}
+
+	// The first worker(s) to respond advertise a nearly-exhausted budget,
+	// which pushes every *later* wait() call out by observe()'s ~1s
+	// minimum backoff. With 4 workers racing 2 jobs each, the initial
+	// burst fires before any header is seen, but the pool as a whole
+	// can't finish faster than that one throttle window.
+	mu.Lock()
+	seen := len(reqTimes)
+	mu.Unlock()
+	if seen < nationCount {
+		t.Fatalf("expected at least %d requests to reach the server, got %d", nationCount, seen)
+	}
+	if elapsed < 900*time.Millisecond {
+		t.Fatalf("limiter did not throttle the pool: batch finished in %s, faster than the advertised budget allows", elapsed)
+	}
+	if elapsed > 10*time.Second {
+		t.Fatalf("batch took too long (%s); limiter may be stuck blocking past its advertised window", elapsed)
+	}
+}
+
+// TestBatchRegionInfoRetriesOn429 checks that a 429 response's Retry-After
+// header holds off the next request for roughly that long, and that the
+// eventually-successful retry still produces a correct result.
+func TestBatchRegionInfoRetriesOn429(t *testing.T) {
+	var reqCount int32
+	var mu sync.Mutex
+	var reqTimes []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqTimes = append(reqTimes, time.Now())
+		mu.Unlock()
+
+		if atomic.AddInt32(&reqCount, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("RateLimit-Remaining", "50")
+		w.Header().Set("RateLimit-Reset", "30")
+		_ = r.ParseForm()
+		if r.FormValue("q") == "region" {
+			fmt.Fprint(w, nationRegionXML)
+		} else {
+			fmt.Fprint(w, regionInfoXML)
+		}
+	}))
+	defer server.Close()
+
+	withTestClient(t, server)
+
+	results := BatchRegionInfo([]string{"retried_nation"}, 1)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatalf("unexpected error after retry: %v", results[0].Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reqTimes) < 2 {
+		t.Fatalf("expected the client to retry after the 429, got %d request(s)", len(reqTimes))
+	}
+	gap := reqTimes[1].Sub(reqTimes[0])
+	if gap < 900*time.Millisecond {
+		t.Fatalf("retry fired before the advertised Retry-After elapsed: only %s later", gap)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+QUORATE's NationStates API client - client construction
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultTimeout is used when Options.Timeout is left zero.
+const defaultTimeout = 30 * time.Second
+
+// Options configures a Client. Every field is optional; the zero value of
+// Options produces a client with a 30s timeout whose transport honors
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY.
+type Options struct {
+	// UserAgent is sent with every request. If empty, call SetUserAgent
+	// on the resulting Client before using it.
+	UserAgent string
+	// Timeout bounds each request. Defaults to 30s.
+	Timeout time.Duration
+	// Transport overrides the client's http.RoundTripper. Defaults to an
+	// *http.Transport that honors HTTP_PROXY/HTTPS_PROXY/NO_PROXY via
+	// http.ProxyFromEnvironment. Tests can inject one pointed at an
+	// httptest.Server, or one that wraps requests for retries/tracing.
+	Transport http.RoundTripper
+}
+
+// Client is a NationStates API client. Use NewClient to construct one;
+// the zero value is not usable. The package-level functions (SetUserAgent,
+// GetProposalApprovals, ...) are thin wrappers around a package-level
+// default Client, kept for backward compatibility with callers that don't
+// need a custom transport or proxy.
+type Client struct {
+	httpClient *http.Client
+	userAgent  string
+}
+
+// NewClient builds a Client from opts, falling back to sane defaults for
+// anything left unset.
+func NewClient(opts Options) *Client {
+	transport := opts.Transport
+	if transport == nil {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Client{
+		httpClient: &http.Client{Transport: transport, Timeout: timeout},
+		userAgent:  opts.UserAgent,
+	}
+}
+
+// SetUserAgent sets the user agent c identifies itself with, in the same
+// "quorate vX.Y.Z developed by nation=Notanam, in use by nation=..." form
+// the API expects.
+func (c *Client) SetUserAgent(mainNation string) {
+	c.userAgent = url.QueryEscape("quorate v" + version + " developed by nation=Notanam, in use by nation=" + mainNation)
+}
+
+var defaultClient = NewClient(Options{})
+
+// SetUserAgent sets the user agent for the package-level default client.
+func SetUserAgent(mainNation string) {
+	defaultClient.SetUserAgent(mainNation)
+}
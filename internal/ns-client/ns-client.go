@@ -28,6 +28,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"quorate/internal/paths"
 	"slices"
 	"strconv"
 	"strings"
@@ -37,9 +39,6 @@ import (
 const apiUrl = "https://www.nationstates.net/cgi-bin/api.cgi"
 const version = "1.0.0"
 
-var httpClient = http.DefaultClient
-var userAgent = ""
-
 type RegionInfo struct {
 	DelEndos     int
 	SecondEndos  int
@@ -49,14 +48,10 @@ type RegionInfo struct {
 	LastMinor    int64
 }
 
-func SetUserAgent(mainNation string) {
-	userAgent = url.QueryEscape("quorate v" + version + " developed by nation=Notanam, in use by nation=" + mainNation)
-}
-
-func makeAPIRequest[T ApiRootNode](data url.Values) (returned T, error error) {
+func makeAPIRequest[T ApiRootNode](c *Client, data url.Values) (returned T, error error) {
 	var zero T
 
-	if userAgent == "" {
+	if c.userAgent == "" {
 		return zero, errors.New("no user agent set")
 	}
 
@@ -65,11 +60,12 @@ func makeAPIRequest[T ApiRootNode](data url.Values) (returned T, error error) {
 		return zero, err
 	}
 
-	req.Header.Add("User-Agent", userAgent)
+	req.Header.Add("User-Agent", c.userAgent)
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	for {
-		result, err := httpClient.Do(req)
+		apiLimiter.wait()
+		result, err := c.httpClient.Do(req)
 		if err != nil {
 			return zero, err
 		}
@@ -78,13 +74,14 @@ func makeAPIRequest[T ApiRootNode](data url.Values) (returned T, error error) {
 			retryAfter := result.Header.Get("Retry-After")
 			log.Print("Hit rate limit, trying again in " + retryAfter)
 			intRetry, _ := strconv.Atoi(retryAfter)
-			time.Sleep(time.Duration(intRetry) * time.Second)
+			apiLimiter.observeRetryAfter(intRetry)
 		} else {
-			if remaining, _ := strconv.Atoi(result.Header.Get("RateLimit-Remaining")); remaining <= 7 {
+			remaining, _ := strconv.Atoi(result.Header.Get("RateLimit-Remaining"))
+			reset, _ := strconv.Atoi(result.Header.Get("RateLimit-Reset"))
+			if remaining > 0 && remaining <= 7 {
 				log.Print("Getting close to rate limit... slowing down")
-				reset, _ := strconv.Atoi(result.Header.Get("RateLimit-Reset"))
-				time.Sleep(time.Duration(reset/remaining+1) * time.Second)
 			}
+			apiLimiter.observe(remaining, reset)
 			if result.StatusCode != http.StatusOK {
 				return zero, fmt.Errorf("bad status: %s", result.Status)
 			}
@@ -104,12 +101,12 @@ func makeAPIRequest[T ApiRootNode](data url.Values) (returned T, error error) {
 	}
 }
 
-func GetProposalApprovals(id string) (delegates []string, error error) {
+func (c *Client) GetProposalApprovals(id string) (delegates []string, error error) {
 	data := url.Values{}
 	data.Add("wa", "2")
 	data.Add("q", "proposals")
 
-	xmlProposals, err := makeAPIRequest[WaProposalsOuter](data)
+	xmlProposals, err := makeAPIRequest[WaProposalsOuter](c, data)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +118,7 @@ func GetProposalApprovals(id string) (delegates []string, error error) {
 	}
 
 	data.Set("wa", "1")
-	xmlProposals, err = makeAPIRequest[WaProposalsOuter](data)
+	xmlProposals, err = makeAPIRequest[WaProposalsOuter](c, data)
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +132,13 @@ func GetProposalApprovals(id string) (delegates []string, error error) {
 	return nil, errors.New("proposal not found")
 }
 
-func GetRegioninfo(region string) (regionInfo RegionInfo, error error) {
+// GetProposalApprovals fetches id's approving delegates using the
+// package-level default client.
+func GetProposalApprovals(id string) ([]string, error) {
+	return defaultClient.GetProposalApprovals(id)
+}
+
+func (c *Client) GetRegioninfo(region string) (regionInfo RegionInfo, error error) {
 	var zero RegionInfo
 
 	data := url.Values{}
@@ -143,7 +146,7 @@ func GetRegioninfo(region string) (regionInfo RegionInfo, error error) {
 	data.Add("q", "censusranks+tags+lastmajorupdate+lastminorupdate")
 	data.Add("scale", "66")
 
-	xmlRegion, err := makeAPIRequest[RegionOuter](data)
+	xmlRegion, err := makeAPIRequest[RegionOuter](c, data)
 	if err != nil {
 		return zero, err
 	}
@@ -163,12 +166,18 @@ func GetRegioninfo(region string) (regionInfo RegionInfo, error error) {
 	}, nil
 }
 
-func GetNationRegion(nation string) (regionName string, error error) {
+// GetRegioninfo fetches region's info using the package-level default
+// client.
+func GetRegioninfo(region string) (RegionInfo, error) {
+	return defaultClient.GetRegioninfo(region)
+}
+
+func (c *Client) GetNationRegion(nation string) (regionName string, error error) {
 	data := url.Values{}
 	data.Add("nation", nation)
 	data.Add("q", "region")
 
-	xmlRegion, err := makeAPIRequest[NationRegion](data)
+	xmlRegion, err := makeAPIRequest[NationRegion](c, data)
 	if err != nil {
 		return "", err
 	}
@@ -176,73 +185,148 @@ func GetNationRegion(nation string) (regionName string, error error) {
 	return xmlRegion.Region, nil
 }
 
-func GetRegionDump() (error error) {
-	err := downloadRegionDump()
+// GetNationRegion fetches nation's home region using the package-level
+// default client.
+func GetNationRegion(nation string) (string, error) {
+	return defaultClient.GetNationRegion(nation)
+}
+
+// staleAfter is how long a cached regions dump is considered fresh enough
+// to skip redownloading.
+const staleAfter = 24 * time.Hour
+
+// RegionDumpPath returns the path quorate caches the regions dump at,
+// creating the cache directory if it doesn't exist yet. The dump is cached
+// in its original gzip-compressed form; callers stream-decompress it
+// rather than quorate keeping a second, uncompressed copy on disk.
+func RegionDumpPath() (string, error) {
+	cacheDir, err := paths.CacheDir()
 	if err != nil {
-		return err
+		return "", err
 	}
+	if err := paths.EnsureDir(cacheDir); err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "regions.xml.gz"), nil
+}
 
-	err = unzipRegionDump()
+// GetRegionDump ensures a regions dump is cached locally and returns its
+// path. If a cached dump already exists and is less than 24h old, the
+// download is skipped unless redownload is true. A legacy ./regions.xml
+// from before XDG support is migrated (and compressed) into the cache dir
+// on first run.
+func (c *Client) GetRegionDump(redownload bool) (string, error) {
+	dumpPath, err := RegionDumpPath()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_ = os.Remove("regions.xml.gz")
+	if err := migrateLegacyDump(dumpPath); err != nil {
+		return "", err
+	}
 
-	return nil
+	if !redownload {
+		if info, err := os.Stat(dumpPath); err == nil && time.Since(info.ModTime()) < staleAfter {
+			return dumpPath, nil
+		}
+	}
+
+	return dumpPath, c.downloadRegionDump(dumpPath)
 }
 
-func downloadRegionDump() (error error) {
-	if userAgent == "" {
-		return errors.New("no user agent set")
+// GetRegionDump ensures a regions dump is cached locally using the
+// package-level default client. See Client.GetRegionDump.
+func GetRegionDump(redownload bool) (string, error) {
+	return defaultClient.GetRegionDump(redownload)
+}
+
+// migrateLegacyDump gzip-compresses a pre-XDG, uncompressed ./regions.xml
+// into the cache dir the first time quorate is run after upgrading, so
+// users don't lose their already-downloaded dump.
+func migrateLegacyDump(dumpPath string) error {
+	if _, err := os.Stat(dumpPath); err == nil {
+		return nil
 	}
 
-	out, err := os.Create("regions.xml.gz")
+	legacyPath := "regions.xml"
+	legacyInfo, err := os.Stat(legacyPath)
 	if err != nil {
-		return err
+		return nil
 	}
-	defer out.Close()
+	legacyMtime := legacyInfo.ModTime()
 
-	req, err := http.NewRequest("GET", "https://www.nationstates.net/pages/regions.xml.gz", nil)
+	legacyFile, err := os.Open(legacyPath)
 	if err != nil {
-		return err
+		return nil
 	}
 
-	req.Header.Add("User-Agent", userAgent)
-	result, err := httpClient.Do(req)
+	out, err := os.Create(dumpPath)
 	if err != nil {
+		legacyFile.Close()
 		return err
 	}
-	if result.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", result.Status)
+
+	gzWriter := gzip.NewWriter(out)
+	if _, err := io.Copy(gzWriter, legacyFile); err != nil {
+		legacyFile.Close()
+		out.Close()
+		return err
+	}
+	if err := gzWriter.Close(); err != nil {
+		legacyFile.Close()
+		out.Close()
+		return err
 	}
-	defer result.Body.Close()
 
-	_, err = io.Copy(out, result.Body)
-	if err != nil {
+	// Close both files explicitly (rather than deferring) before removing
+	// the legacy one and restamping the migrated one's mtime: an open
+	// handle can't be unlinked on Windows, and Chtimes on a still-open
+	// file risks the OS clobbering it back to "now" on close.
+	if err := legacyFile.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
 		return err
 	}
 
-	return nil
+	if err := os.Remove(legacyPath); err != nil {
+		return err
+	}
+
+	// Carry the legacy file's mtime over to the migrated copy so
+	// GetRegionDump's staleness check doesn't treat an already-stale dump
+	// as fresh just because it was rewritten right now.
+	return os.Chtimes(dumpPath, legacyMtime, legacyMtime)
 }
 
-func unzipRegionDump() (error error) {
-	uncompressed, err := os.Create("regions.xml")
+func (c *Client) downloadRegionDump(gzPath string) (error error) {
+	if c.userAgent == "" {
+		return errors.New("no user agent set")
+	}
+
+	out, err := os.Create(gzPath)
 	if err != nil {
 		return err
 	}
-	defer uncompressed.Close()
+	defer out.Close()
 
-	zippedDump, err := os.Open("regions.xml.gz")
+	req, err := http.NewRequest("GET", "https://www.nationstates.net/pages/regions.xml.gz", nil)
 	if err != nil {
 		return err
 	}
-	defer zippedDump.Close()
 
-	gzReader, err := gzip.NewReader(zippedDump)
-	defer gzReader.Close()
+	req.Header.Add("User-Agent", c.userAgent)
+	result, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if result.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", result.Status)
+	}
+	defer result.Body.Close()
 
-	_, err = io.Copy(uncompressed, gzReader)
+	_, err = io.Copy(out, result.Body)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+/*
+QUORATE's NationStates API client - dump migration tests
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestMigrateLegacyDumpPreservesMtime guards against the migrated dump
+// looking freshly-downloaded right after an upgrade: GetRegionDump's
+// staleness check trusts the cache file's mtime, so a gzip-copy that
+// stamps "now" instead of carrying over the legacy file's mtime would
+// silently skip a redownload that's actually overdue.
+func TestMigrateLegacyDumpPreservesMtime(t *testing.T) {
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+
+	if err := os.WriteFile("regions.xml", []byte("<REGIONS></REGIONS>"), 0644); err != nil {
+		t.Fatalf("writing legacy dump: %v", err)
+	}
+
+	staleMtime := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes("regions.xml", staleMtime, staleMtime); err != nil {
+		t.Fatalf("backdating legacy dump: %v", err)
+	}
+
+	dumpPath := filepath.Join(t.TempDir(), "regions.xml.gz")
+	if err := migrateLegacyDump(dumpPath); err != nil {
+		t.Fatalf("migrateLegacyDump: %v", err)
+	}
+
+	info, err := os.Stat(dumpPath)
+	if err != nil {
+		t.Fatalf("stat migrated dump: %v", err)
+	}
+
+	if diff := info.ModTime().Sub(staleMtime); diff < -time.Second || diff > time.Second {
+		t.Fatalf("migrated dump's mtime is %s, want ~%s (legacy file's original mtime)", info.ModTime(), staleMtime)
+	}
+
+	if time.Since(info.ModTime()) < staleAfter {
+		t.Fatalf("migrated dump reads as fresh (%s old); GetRegionDump would wrongly skip the redownload it owes", time.Since(info.ModTime()))
+	}
+
+	if _, err := os.Stat("regions.xml"); !os.IsNotExist(err) {
+		t.Fatalf("expected legacy regions.xml to be removed after migration, stat err = %v", err)
+	}
+}
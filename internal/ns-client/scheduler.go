@@ -0,0 +1,95 @@
+/*
+QUORATE's NationStates API client - request scheduling
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import (
+	"sync"
+	"time"
+)
+
+// releaseStagger is the minimum gap the limiter enforces between two
+// waiters it releases while throttled, so a whole pool of blocked workers
+// doesn't wake up and fire in the same instant once the window reopens.
+const releaseStagger = 300 * time.Millisecond
+
+// rateLimiter centrally tracks the NationStates API's advertised
+// rate-limit budget so that concurrent callers (e.g. the workers in
+// BatchRegionInfo) block cooperatively on a single shared clock instead of
+// each guessing independently from its own response.
+type rateLimiter struct {
+	mu           sync.Mutex
+	blockedUntil time.Time
+	nextRelease  time.Time
+}
+
+var apiLimiter = &rateLimiter{}
+
+// wait blocks the caller until the limiter believes it's safe to send
+// another request. While blockedUntil is in the future, wait also hands
+// out a staggered slot to each caller instead of an all-clear gate, so a
+// burst of workers parked on the same deadline doesn't release in the same
+// instant and immediately retrigger the throttling it just waited out.
+func (l *rateLimiter) wait() {
+	l.mu.Lock()
+	now := time.Now()
+	release := l.blockedUntil
+	if release.Before(now) {
+		// Not currently throttled, so there's no release burst to
+		// stagger; let the caller through immediately.
+		l.nextRelease = now
+		l.mu.Unlock()
+		return
+	}
+	if l.nextRelease.After(release) {
+		release = l.nextRelease
+	}
+	l.nextRelease = release.Add(releaseStagger)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(release))
+}
+
+// observe folds a response's RateLimit-Remaining/RateLimit-Reset headers
+// into the shared budget, pushing blockedUntil out if we're getting close
+// to the limit.
+func (l *rateLimiter) observe(remaining int, resetSeconds int) {
+	if remaining <= 0 || remaining > 7 {
+		return
+	}
+
+	until := time.Now().Add(time.Duration(resetSeconds/remaining+1) * time.Second)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// observeRetryAfter folds a 429 response's Retry-After header into the
+// shared budget.
+func (l *rateLimiter) observeRetryAfter(retryAfterSeconds int) {
+	until := time.Now().Add(time.Duration(retryAfterSeconds) * time.Second)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
@@ -0,0 +1,84 @@
+/*
+QUORATE's NationStates API client - request scheduling tests
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ns_client
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterWaitStaggersBlockedReleases checks that when several
+// callers are parked on the same blockedUntil deadline, wait() hands them
+// staggered release times instead of letting them all fire the instant the
+// window reopens.
+func TestRateLimiterWaitStaggersBlockedReleases(t *testing.T) {
+	l := &rateLimiter{}
+
+	until := time.Now().Add(200 * time.Millisecond)
+	l.mu.Lock()
+	l.blockedUntil = until
+	l.mu.Unlock()
+
+	const waiters = 4
+	releaseTimes := make([]time.Time, waiters)
+	var wg sync.WaitGroup
+	wg.Add(waiters)
+	for i := 0; i < waiters; i++ {
+		go func(i int) {
+			defer wg.Done()
+			l.wait()
+			releaseTimes[i] = time.Now()
+		}(i)
+	}
+	wg.Wait()
+
+	sorted := append([]time.Time(nil), releaseTimes...)
+	for i := 0; i < len(sorted); i++ {
+		for j := i + 1; j < len(sorted); j++ {
+			if sorted[j].Before(sorted[i]) {
+				sorted[i], sorted[j] = sorted[j], sorted[i]
+			}
+		}
+	}
+
+	for i := 1; i < len(sorted); i++ {
+		gap := sorted[i].Sub(sorted[i-1])
+		if gap < releaseStagger-20*time.Millisecond {
+			t.Fatalf("waiter %d released only %s after waiter %d; expected at least ~%s of stagger", i, gap, i-1, releaseStagger)
+		}
+	}
+}
+
+// TestRateLimiterWaitDoesNotStaggerWhenIdle checks that wait() doesn't
+// impose any delay once blockedUntil is in the past, so normal,
+// non-throttled operation isn't slowed down by the release stagger.
+func TestRateLimiterWaitDoesNotStaggerWhenIdle(t *testing.T) {
+	l := &rateLimiter{}
+
+	start := time.Now()
+	l.wait()
+	l.wait()
+	l.wait()
+	elapsed := time.Since(start)
+
+	if elapsed > 50*time.Millisecond {
+		t.Fatalf("wait() delayed unthrottled callers by %s; expected it to return immediately", elapsed)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+QUORATE's XDG-aware path resolution
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package paths resolves where quorate reads and writes its files,
+// following the XDG Base Directory spec on Linux/macOS and falling back
+// to %LocalAppData% on Windows. Every resolver can be overridden by an
+// explicit QUORATE_* environment variable.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// CacheDir returns the directory quorate should cache downloaded dumps
+// in, resolved in order from $QUORATE_CACHE_HOME, $XDG_CACHE_HOME/quorate,
+// and finally a platform default. The directory is not created.
+func CacheDir() (string, error) {
+	if dir := os.Getenv("QUORATE_CACHE_HOME"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "quorate"), nil
+	}
+	return platformDefault("cache")
+}
+
+// ConfigDir returns the directory quorate should read/write its config
+// file in, resolved in order from $QUORATE_CONFIG_HOME,
+// $XDG_CONFIG_HOME/quorate, and finally a platform default. The directory
+// is not created.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("QUORATE_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "quorate"), nil
+	}
+	return platformDefault("config")
+}
+
+// OutputDir returns the directory quorate should write its generated
+// trigger_list.txt/raidFile.txt to: $QUORATE_OUTPUT_DIR if set, otherwise
+// the current working directory.
+func OutputDir() (string, error) {
+	if dir := os.Getenv("QUORATE_OUTPUT_DIR"); dir != "" {
+		return dir, nil
+	}
+	return os.Getwd()
+}
+
+// EnsureDir creates dir (and any missing parents) if it doesn't exist yet.
+func EnsureDir(dir string) error {
+	return os.MkdirAll(dir, 0755)
+}
+
+func platformDefault(kind string) (string, error) {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "quorate"), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case "config":
+		return filepath.Join(home, ".config", "quorate"), nil
+	default:
+		return filepath.Join(home, ".cache", "quorate"), nil
+	}
+}
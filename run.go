@@ -0,0 +1,246 @@
+/*
+QUORATE `run` subcommand
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"quorate/internal/config"
+	nsclient "quorate/internal/ns-client"
+	"quorate/internal/paths"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runCmd is the original end-to-end flow: resolve every setting from, in
+// order, CLI flags, QUORATE_* env vars, quorate.toml, and (only once all
+// of those come up empty) an interactive prompt; then fetch the dump and
+// approvals, compute triggers, and write trigger_list.txt/raidFile.txt.
+// It's the one subcommand meant to be run interactively; the others are
+// meant for scripting.
+func runCmd(args []string) int {
+	var maxEndoCount int
+	var minimumTrigger int
+	var isMinor bool
+	var reDownDump bool
+	var proposalId string
+	var userAgent string
+	var printConfig bool
+
+	flagSet := flag.NewFlagSet("run", flag.ExitOnError)
+	flagSet.StringVar(&userAgent, "useragent", "", "Your user agent")
+	flagSet.StringVar(&proposalId, "proposal", "", "The proposal ID")
+	flagSet.IntVar(&maxEndoCount, "endos", -1, "The maximum endorsement count for a target")
+	flagSet.IntVar(&minimumTrigger, "mintrig", -1, "The minimum trigger time")
+	flagSet.BoolVar(&isMinor, "minor", false, "Use if generating times for minor")
+	flagSet.BoolVar(&reDownDump, "redownload", false, "Use to redownload the daily dump if it's already present")
+	flagSet.BoolVar(&printConfig, "print-config", false, "Print the effective resolved configuration and exit")
+
+	err := flagSet.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	if userAgent == "" {
+		userAgent = os.Getenv("QUORATE_USERAGENT")
+	}
+	if proposalId == "" {
+		proposalId = os.Getenv("QUORATE_PROPOSAL")
+	}
+	if userAgent == "" {
+		userAgent = cfg.UserAgent
+	}
+
+	cfgEndos, cfgMinTrig, cfgIsMinor, cfgIsMinorSet := cfg.ForProposal(proposalId)
+	isMinorSet := flagPassed("minor", flagSet)
+
+	if maxEndoCount < 1 {
+		if envEndos, envErr := strconv.Atoi(os.Getenv("QUORATE_ENDOS")); envErr == nil {
+			maxEndoCount = envEndos
+		} else if cfgEndos > 0 {
+			maxEndoCount = cfgEndos
+		}
+	}
+	if minimumTrigger < 1 {
+		if envMinTrig, envErr := strconv.Atoi(os.Getenv("QUORATE_MINTRIG")); envErr == nil {
+			minimumTrigger = envMinTrig
+		} else if cfgMinTrig > 0 {
+			minimumTrigger = cfgMinTrig
+		}
+	}
+	if !isMinorSet {
+		if envUpdate := os.Getenv("QUORATE_UPDATE"); envUpdate != "" {
+			isMinor = envUpdate == "minor"
+			isMinorSet = true
+		} else if cfgIsMinorSet {
+			isMinor = cfgIsMinor
+			isMinorSet = true
+		}
+	}
+
+	if printConfig {
+		fmt.Printf("useragent = %q\n", userAgent)
+		fmt.Printf("proposal = %q\n", proposalId)
+		fmt.Printf("endos = %d\n", maxEndoCount)
+		fmt.Printf("mintrig = %d\n", minimumTrigger)
+		if isMinorSet {
+			fmt.Printf("update = %q\n", map[bool]string{true: "minor", false: "major"}[isMinor])
+		} else {
+			fmt.Println("update = (unset)")
+		}
+		return 0
+	}
+
+	fmt.Println(Gpl)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for userAgent == "" {
+		fmt.Print("Enter your main nation: ")
+		scanner.Scan()
+		userAgent = scanner.Text()
+		time.Sleep(50 * time.Millisecond) //avoids weird behavior on ctrl C
+	}
+	nsclient.SetUserAgent(userAgent)
+	log.Println("User agent set to " + userAgent)
+	time.Sleep(500 * time.Millisecond)
+
+	for proposalId == "" {
+		fmt.Print("Enter a World Assembly Proposal ID (e.g. proposal_id_12312312): ")
+		scanner.Scan()
+		proposalId = scanner.Text()
+		time.Sleep(50 * time.Millisecond)
+	}
+	log.Println("Proposal set to " + proposalId)
+	time.Sleep(500 * time.Millisecond)
+
+	for endoCountString := ""; err != nil || maxEndoCount < 1; maxEndoCount, err = strconv.Atoi(endoCountString) {
+		fmt.Print("Enter the endo count: ")
+		scanner.Scan()
+		endoCountString = strings.ToLower(scanner.Text())
+		time.Sleep(50 * time.Millisecond)
+	}
+	log.Printf("Endo count set to %d!\n", maxEndoCount)
+	time.Sleep(500 * time.Millisecond)
+
+	for minTrigString := ""; err != nil || minimumTrigger < 1; minimumTrigger, err = strconv.Atoi(minTrigString) {
+		fmt.Print("Enter the minimum trigger time: ")
+		scanner.Scan()
+		minTrigString = strings.ToLower(scanner.Text())
+		time.Sleep(50 * time.Millisecond)
+	}
+	log.Printf("Minimum trigger set to %d!\n", minimumTrigger)
+	time.Sleep(500 * time.Millisecond)
+
+	if !isMinorSet {
+		var choice string
+		for choice != "major" && choice != "minor" {
+			fmt.Print("Which update do you want to search for? (minor/major) ")
+			scanner.Scan()
+			choice = strings.ToLower(scanner.Text())
+			time.Sleep(50 * time.Millisecond)
+		}
+		isMinor = choice == "minor"
+	}
+
+	dumpPath, err := nsclient.RegionDumpPath()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	forceRedownload := true
+	if info, statErr := os.Stat(dumpPath); statErr == nil && time.Since(info.ModTime()) < 24*time.Hour {
+		if flagPassed("redownload", flagSet) {
+			forceRedownload = reDownDump
+		} else {
+			choice := "qwerty"
+			for choice != "y" && choice != "n" && choice != "" {
+				fmt.Print("Daily regions dump already downloaded! Download again? (Y/n) ")
+				scanner.Scan()
+				choice = strings.ToLower(scanner.Text())
+				time.Sleep(50 * time.Millisecond)
+			}
+			forceRedownload = choice != "n"
+		}
+	}
+
+	log.Println("Getting region dump...")
+	if _, err := nsclient.GetRegionDump(forceRedownload); err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Println("Region dump saved!")
+
+	hittable, err := fetchHittable(proposalId, maxEndoCount, isMinor, nsclient.DefaultWorkers)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if len(hittable) == 0 {
+		log.Print("No regions are hittable! Press enter to exit...")
+		scanner.Scan()
+		return 0
+	}
+	log.Printf("Checks done! %d regions are hittable!\n", len(hittable))
+
+	log.Println("Scanning regions dump for triggers...")
+	firstUpdateRegion, firstUpdateTime, err := computeTriggers(hittable, dumpPath, isMinor, minimumTrigger)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Println("Triggers obtained!")
+
+	outputDir, err := paths.OutputDir()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	log.Println("Creating trigger_list.txt and raidFile.txt...")
+	triggerList, raidFile := renderTriggerFiles(hittable, firstUpdateRegion, firstUpdateTime)
+
+	if err := os.WriteFile(filepath.Join(outputDir, "trigger_list.txt"), []byte(triggerList), 0644); err != nil {
+		log.Print(err)
+		return 1
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "raidFile.txt"), []byte(raidFile), 0644); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	log.Print("Files created! Press enter to exit...")
+	scanner.Scan()
+	return 0
+}
@@ -0,0 +1,242 @@
+/*
+QUORATE `triggers` subcommand
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	nsclient "quorate/internal/ns-client"
+	"quorate/internal/paths"
+	"sort"
+	"strings"
+	"time"
+)
+
+// triggersCmd reads a []Hit produced by `quorate approvals`, matches each
+// one up against a regions dump, and writes trigger_list.txt/raidFile.txt.
+// It does no API calls of its own, so triggers can be regenerated (e.g.
+// with a different -mintrig) without re-querying NationStates.
+func triggersCmd(args []string) int {
+	flagSet := flag.NewFlagSet("triggers", flag.ExitOnError)
+	var input string
+	var dumpPath string
+	var minimumTrigger int
+	var isMinor bool
+	var triggerListPath string
+	var raidFilePath string
+	flagSet.StringVar(&input, "input", "-", "Path to the approvals JSON ('-' for stdin)")
+	flagSet.StringVar(&dumpPath, "dump", "", "Path to the regions dump (defaults to the XDG cache dir)")
+	flagSet.IntVar(&minimumTrigger, "mintrig", -1, "The minimum trigger time")
+	flagSet.BoolVar(&isMinor, "minor", false, "Use if generating times for minor")
+	flagSet.StringVar(&triggerListPath, "trigger-list", "", "Where to write the trigger list (defaults to $QUORATE_OUTPUT_DIR or CWD)")
+	flagSet.StringVar(&raidFilePath, "raid-file", "", "Where to write the raid file (defaults to $QUORATE_OUTPUT_DIR or CWD)")
+	_ = flagSet.Parse(args)
+
+	if minimumTrigger < 1 {
+		log.Print("-mintrig (>=1) is required")
+		return 1
+	}
+
+	hittable, err := readHittable(input)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if len(hittable) == 0 {
+		log.Print("No regions are hittable!")
+		return 0
+	}
+
+	if dumpPath == "" {
+		dumpPath, err = nsclient.RegionDumpPath()
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+
+	log.Println("Scanning regions dump for triggers...")
+	firstUpdateRegion, firstUpdateTime, err := computeTriggers(hittable, dumpPath, isMinor, minimumTrigger)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Println("Triggers obtained!")
+
+	if triggerListPath == "" || raidFilePath == "" {
+		outputDir, err := paths.OutputDir()
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		if triggerListPath == "" {
+			triggerListPath = filepath.Join(outputDir, "trigger_list.txt")
+		}
+		if raidFilePath == "" {
+			raidFilePath = filepath.Join(outputDir, "raidFile.txt")
+		}
+	}
+
+	log.Println("Creating trigger_list.txt and raidFile.txt...")
+	triggerList, raidFile := renderTriggerFiles(hittable, firstUpdateRegion, firstUpdateTime)
+
+	if err := os.WriteFile(triggerListPath, []byte(triggerList), 0644); err != nil {
+		log.Print(err)
+		return 1
+	}
+	if err := os.WriteFile(raidFilePath, []byte(raidFile), 0644); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	log.Print("Files created!")
+	return 0
+}
+
+func readHittable(input string) ([]Hit, error) {
+	var raw []byte
+	var err error
+	if input == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var hittable []Hit
+	if err := json.Unmarshal(raw, &hittable); err != nil {
+		return nil, err
+	}
+	return hittable, nil
+}
+
+// computeTriggers sorts hittable by update time and streams the regions
+// dump at dumpPath once, matching each hittable target against the dump
+// to find the most recent prior update at least minimumTrigger seconds
+// earlier to use as its trigger. The dump is never held in memory as a
+// whole slice; regions are decoded and discarded one at a time.
+func computeTriggers(hittable []Hit, dumpPath string, isMinor bool, minimumTrigger int) (firstUpdateRegion string, firstUpdateTime int64, err error) {
+	log.Println("Sorting regions by update time...")
+	sort.Slice(hittable, func(i, j int) bool {
+		return hittable[i].UpdateTime < hittable[j].UpdateTime
+	})
+	log.Println("Regions sorted!")
+
+	dump, err := openRegionsDump(dumpPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer dump.Close()
+
+	updateTimes := make(map[int64]string)
+	seenAny := false
+	hitIndex := 0
+
+	log.Println("Getting triggers for regions...")
+	err = streamRegions(dump, func(region RegionDump) (bool, error) {
+		canonName := strings.Replace(strings.ToLower(region.Name), " ", "_", -1)
+
+		var regionUpdate int64
+		if isMinor {
+			regionUpdate = region.LastMinor
+		} else {
+			regionUpdate = region.LastMajor
+		}
+
+		if !seenAny {
+			seenAny = true
+			firstUpdateRegion = region.Name
+			firstUpdateTime = regionUpdate
+		}
+
+		if hitIndex == len(hittable) {
+			return true, nil
+		}
+
+		if _, exists := updateTimes[regionUpdate]; !exists {
+			updateTimes[regionUpdate] = canonName
+		}
+
+		//edge case where region doesn't exit in daily dump
+		if hitIndex != len(hittable)-1 && canonName == hittable[hitIndex+1].Name {
+			hitIndex++
+		}
+
+		if canonName == hittable[hitIndex].Name {
+			hittable[hitIndex].UpdateTime = regionUpdate
+			for i := 0; true; i++ {
+				trigTime := regionUpdate - int64(minimumTrigger+i)
+				if trigRegion, exists := updateTimes[trigTime]; exists {
+					hittable[hitIndex].TriggerTime = trigTime
+					hittable[hitIndex].TriggerRegion = trigRegion
+					hitIndex++
+					break
+				} else if trigTime <= firstUpdateTime {
+					hittable[hitIndex].TriggerTime = firstUpdateTime
+					hittable[hitIndex].TriggerRegion = firstUpdateRegion
+					hitIndex++
+					break
+				}
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if !seenAny {
+		return "", 0, errors.New("regions dump is empty")
+	}
+
+	return firstUpdateRegion, firstUpdateTime, nil
+}
+
+func renderTriggerFiles(hittable []Hit, firstUpdateRegion string, firstUpdateTime int64) (triggerList string, raidFile string) {
+	var triggerFileBuilder strings.Builder
+	var raidFileBuilder strings.Builder
+
+	for i, hit := range hittable {
+		if hit.TriggerRegion == "" {
+			continue
+		}
+
+		firstRegionTimeDiff := (time.Duration(hit.UpdateTime-firstUpdateTime) * time.Second).String()
+		triggerTimeDiff := time.Duration(hit.UpdateTime-hit.TriggerTime) * time.Second
+
+		triggerFileBuilder.WriteString(hit.TriggerRegion + "\n")
+		raidFileBuilder.WriteString(fmt.Sprintf("%d) https://www.nationstates.net/region=%s (%s)\n", i+1, hit.Name,
+			firstRegionTimeDiff))
+		if hit.IsDeltip {
+			raidFileBuilder.WriteString(fmt.Sprintf("ENDORSE: https://www.nationstates.net/nation=%s\n", hit.SecondNation))
+		}
+		raidFileBuilder.WriteString(fmt.Sprintf("\ta) https://www.nationstates.net/template-overall=none/region=%s (%s)\n\n",
+			hit.TriggerRegion, triggerTimeDiff))
+	}
+
+	return triggerFileBuilder.String(), raidFileBuilder.String()
+}
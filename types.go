@@ -0,0 +1,115 @@
+/*
+QUORATE shared types
+Copyright (C) 2024 Nota
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+)
+
+// Hit is a single hittable (or deltippable) target, threaded through the
+// approvals and triggers steps. It's the record that gets marshalled to
+// JSON between the two subcommands.
+type Hit struct {
+	Name          string
+	Delegate      string
+	SecondNation  string
+	IsDeltip      bool
+	UpdateTime    int64
+	TriggerRegion string
+	TriggerTime   int64
+}
+
+type RegionDump struct {
+	Name      string `xml:"NAME"`
+	LastMinor int64  `xml:"LASTMINORUPDATE"`
+	LastMajor int64  `xml:"LASTMAJORUPDATE"`
+}
+
+// gzipFile closes both the gzip reader and the underlying file it wraps.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	gzErr := g.Reader.Close()
+	fErr := g.f.Close()
+	if gzErr != nil {
+		return gzErr
+	}
+	return fErr
+}
+
+// openRegionsDump opens a regions dump for streaming, transparently
+// gzip-decompressing it if path ends in .gz.
+func openRegionsDump(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gzReader, f: f}, nil
+}
+
+// streamRegions decodes r's <REGION> elements one at a time, calling fn
+// for each. fn returns stop=true to end the scan early (e.g. once every
+// hittable target has been matched) without reading the rest of the dump.
+func streamRegions(r io.Reader, fn func(RegionDump) (stop bool, err error)) error {
+	decoder := xml.NewDecoder(bufio.NewReader(r))
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "REGION" {
+			continue
+		}
+
+		var region RegionDump
+		if err := decoder.DecodeElement(&region, &start); err != nil {
+			return err
+		}
+
+		stop, err := fn(region)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+}